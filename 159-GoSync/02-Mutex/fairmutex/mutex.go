@@ -0,0 +1,198 @@
+// Package fairmutex实现了一个现代版本的Mutex，对应mutex1包里2008年那版
+// 之后sync.Mutex经历的几次演进：状态字从单纯的"是否被持有"变成一个打包了
+// locked/woken/starving/等待者数量的状态字，并且引入了饥饿模式来避免
+// goroutine被无限插队。
+package fairmutex
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// state的低3位分别是locked、woken、starving标记，剩下的高位打包等待者数量，
+// 布局和运行时sync.Mutex的state字段完全一致
+const (
+	mutexLocked      = 1 << iota // 锁是否被持有
+	mutexWoken                   // 是否已经有一个goroutine被唤醒，正在尝试抢锁
+	mutexStarving                // 是否处于饥饿模式
+	mutexWaiterShift = iota
+)
+
+// starvationThreshold是进入饥饿模式的等待时长阈值：一个goroutine等锁
+// 超过1ms，就说明新来的goroutine一直在抢占它，需要切换到饥饿模式
+const starvationThreshold = time.Millisecond
+
+// activeSpinIter是正常模式下自旋尝试抢锁的最大次数，超过这个次数还没抢到
+// 就老老实实排队。我们没有运行时里procyield那样的机制，用Gosched近似。
+const activeSpinIter = 4
+
+// Mutex是一个公平的、带饥饿模式的互斥锁。零值即可用。
+type Mutex struct {
+	state int32
+	sem   waiterQueue
+}
+
+// Lock请求锁。正常模式下新来的goroutine会和被唤醒的等待者一起抢锁，
+// 抢不到就排队；饥饿模式下锁会被直接交给队首等待者，新来的goroutine
+// 不会插队。
+func (m *Mutex) Lock() {
+	if atomic.CompareAndSwapInt32(&m.state, 0, mutexLocked) {
+		return
+	}
+	m.lockSlow()
+}
+
+// TryLock在不阻塞的情况下尝试获取锁，返回是否成功。
+// 饥饿模式下TryLock总是失败，把锁让给排队的等待者，避免破坏公平性。
+func (m *Mutex) TryLock() bool {
+	old := atomic.LoadInt32(&m.state)
+	if old&(mutexLocked|mutexStarving) != 0 {
+		return false
+	}
+	return atomic.CompareAndSwapInt32(&m.state, old, old|mutexLocked)
+}
+
+func (m *Mutex) lockSlow() {
+	var waitStart time.Time
+	starving := false
+	awoke := false
+	iter := 0
+	old := atomic.LoadInt32(&m.state)
+	for {
+		// 正常模式下，锁被占用但没进入饥饿模式时可以自旋等一下，
+		// 期望持有者很快释放，省去一次排队/唤醒的开销
+		if old&(mutexLocked|mutexStarving) == mutexLocked && iter < activeSpinIter {
+			if !awoke && old&mutexWoken == 0 && old>>mutexWaiterShift != 0 &&
+				atomic.CompareAndSwapInt32(&m.state, old, old|mutexWoken) {
+				awoke = true
+			}
+			runtime.Gosched()
+			iter++
+			old = atomic.LoadInt32(&m.state)
+			continue
+		}
+
+		new := old
+		if old&mutexStarving == 0 {
+			new |= mutexLocked // 非饥饿模式下参与竞争
+		}
+		if old&(mutexLocked|mutexStarving) != 0 {
+			new += 1 << mutexWaiterShift // 排队，等待者数量加一
+		}
+		if starving && old&mutexLocked != 0 {
+			new |= mutexStarving // 等太久了，切换到饥饿模式
+		}
+		if awoke {
+			// 唤醒标记必须清掉，不管是我们抢到了锁还是要继续排队
+			new &^= mutexWoken
+		}
+
+		if atomic.CompareAndSwapInt32(&m.state, old, new) {
+			if old&(mutexLocked|mutexStarving) == 0 {
+				break // 竞争到了锁
+			}
+			if waitStart.IsZero() {
+				waitStart = time.Now()
+			}
+			// 简化说明：runtime版的sync.Mutex在这里会区分queueLifo——
+			// 之前排过队、抢唤醒失败又要再排一次的goroutine会插到队首而
+			// 不是队尾，减少尾延迟。我们的waiterQueue没有区分插队位置，
+			// 这里的goroutine总是排到队尾。饥饿模式的1ms阈值仍然兜底了
+			// 公平性（长期不会饿死），只是在正常模式下尾延迟会略差一点。
+			ch := m.sem.enqueue()
+			<-ch // 排队等待被唤醒或者被直接交接锁
+
+			starving = starving || time.Since(waitStart) > starvationThreshold
+			old = atomic.LoadInt32(&m.state)
+			if old&mutexStarving != 0 {
+				// 饥饿模式下，锁被直接交接给了我们，不需要再抢一次CAS；
+				// 只需要把等待者数量减一，如果我们是最后一个等待者
+				// 或者已经不饥饿了，就退出饥饿模式
+				delta := int32(mutexLocked - 1<<mutexWaiterShift)
+				if !starving || old>>mutexWaiterShift == 1 {
+					delta -= mutexStarving
+				}
+				atomic.AddInt32(&m.state, delta)
+				break
+			}
+			awoke = true
+			iter = 0
+			continue
+		}
+		old = atomic.LoadInt32(&m.state)
+	}
+}
+
+// Unlock释放锁。
+func (m *Mutex) Unlock() {
+	new := atomic.AddInt32(&m.state, -mutexLocked)
+	if new == 0 {
+		return // 没有等待者
+	}
+	m.unlockSlow(new)
+}
+
+func (m *Mutex) unlockSlow(state int32) {
+	if state&mutexStarving == 0 {
+		old := state
+		for {
+			// 没有等待者，或者已经有goroutine被唤醒/抢到锁/进入饥饿模式，
+			// 都不需要我们再唤醒谁
+			if old>>mutexWaiterShift == 0 || old&(mutexLocked|mutexWoken|mutexStarving) != 0 {
+				return
+			}
+			new := (old - 1<<mutexWaiterShift) | mutexWoken
+			if atomic.CompareAndSwapInt32(&m.state, old, new) {
+				m.sem.wakeOne()
+				return
+			}
+			old = atomic.LoadInt32(&m.state)
+		}
+	}
+	// 饥饿模式：直接把锁交接给队首的等待者，不清mutexLocked，
+	// 因为锁的所有权此刻正在从当前goroutine交接给被唤醒的那个goroutine
+	m.sem.wakeOne()
+}
+
+// waiterQueue是一个先进先出的计数信号量：每个等待者拥有自己独立的channel，
+// wakeOne总是唤醒排在最前面的那个，从而实现队首优先的公平交接。
+//
+// state里的等待者数量是先自增的，enqueue()真正把channel挂进队列还要
+// 晚一点才发生，所以wakeOne()有可能在对应的enqueue()执行之前就被调用；
+// 这种情况下我们记一个permit，等enqueue()来了直接消费掉，而不是把这次
+// 唤醒凭空丢掉。
+type waiterQueue struct {
+	mu      sync.Mutex
+	waiters []chan struct{}
+	permits int
+}
+
+func (q *waiterQueue) enqueue() <-chan struct{} {
+	q.mu.Lock()
+	if q.permits > 0 {
+		q.permits--
+		q.mu.Unlock()
+		ch := make(chan struct{})
+		close(ch) // 之前的wakeOne已经发生了，直接给一个立即就绪的channel
+		return ch
+	}
+	ch := make(chan struct{})
+	q.waiters = append(q.waiters, ch)
+	q.mu.Unlock()
+	return ch
+}
+
+func (q *waiterQueue) wakeOne() {
+	q.mu.Lock()
+	if len(q.waiters) == 0 {
+		q.permits++ // 还没有人在排队，先记一个许可，留给之后的enqueue消费
+		q.mu.Unlock()
+		return
+	}
+	ch := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	q.mu.Unlock()
+	close(ch)
+}
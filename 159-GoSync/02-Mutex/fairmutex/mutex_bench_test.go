@@ -0,0 +1,89 @@
+package fairmutex
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// locker是Mutex和sync.Mutex的公共子集，方便基准测试共用同一套跑法
+type locker interface {
+	Lock()
+	Unlock()
+}
+
+func runContentionBench(b *testing.B, l locker, goroutines int) {
+	var wg sync.WaitGroup
+	work := b.N
+	perGoroutine := work / goroutines
+	if perGoroutine == 0 {
+		perGoroutine = 1
+	}
+
+	b.ResetTimer()
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				l.Lock()
+				l.Unlock() //nolint:staticcheck // 基准测试只关心加锁/解锁本身的开销
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func BenchmarkFairMutex_2(b *testing.B)  { runContentionBench(b, &Mutex{}, 2) }
+func BenchmarkFairMutex_8(b *testing.B)  { runContentionBench(b, &Mutex{}, 8) }
+func BenchmarkFairMutex_64(b *testing.B) { runContentionBench(b, &Mutex{}, 64) }
+
+func BenchmarkSyncMutex_2(b *testing.B)  { runContentionBench(b, &sync.Mutex{}, 2) }
+func BenchmarkSyncMutex_8(b *testing.B)  { runContentionBench(b, &sync.Mutex{}, 8) }
+func BenchmarkSyncMutex_64(b *testing.B) { runContentionBench(b, &sync.Mutex{}, 64) }
+
+// measureTailLatency统计goroutines个goroutine各自抢n次锁的Lock耗时分布，
+// 返回p50/p99，用来对比公平模式下尾延迟是否更稳定
+func measureTailLatency(l locker, goroutines, n int) (p50, p99 time.Duration) {
+	var mu sync.Mutex
+	var samples []time.Duration
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				start := time.Now()
+				l.Lock()
+				elapsed := time.Since(start)
+				l.Unlock()
+
+				mu.Lock()
+				samples = append(samples, elapsed)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)*50/100], samples[len(samples)*99/100]
+}
+
+func BenchmarkFairMutex_TailLatency_64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p50, p99 := measureTailLatency(&Mutex{}, 64, 20)
+		b.ReportMetric(float64(p50.Nanoseconds()), "p50-ns")
+		b.ReportMetric(float64(p99.Nanoseconds()), "p99-ns")
+	}
+}
+
+func BenchmarkSyncMutex_TailLatency_64(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		p50, p99 := measureTailLatency(&sync.Mutex{}, 64, 20)
+		b.ReportMetric(float64(p50.Nanoseconds()), "p50-ns")
+		b.ReportMetric(float64(p99.Nanoseconds()), "p99-ns")
+	}
+}
@@ -0,0 +1,124 @@
+package fairmutex
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMutexMutualExclusion(t *testing.T) {
+	var m Mutex
+	var counter int
+	var wg sync.WaitGroup
+
+	const goroutines = 50
+	const iterations = 1000
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				m.Lock()
+				counter++
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if want := goroutines * iterations; counter != want {
+		t.Fatalf("counter = %d; want %d", counter, want)
+	}
+}
+
+func TestMutexTryLock(t *testing.T) {
+	var m Mutex
+	if !m.TryLock() {
+		t.Fatalf("TryLock() on an unlocked Mutex should succeed")
+	}
+	if m.TryLock() {
+		t.Fatalf("TryLock() on a locked Mutex should fail")
+	}
+	m.Unlock()
+	if !m.TryLock() {
+		t.Fatalf("TryLock() after Unlock should succeed")
+	}
+	m.Unlock()
+}
+
+// TestMutexNoLongTermStarvation验证在持续高强度的竞争下，饥饿模式能够
+// 保证每个goroutine单次获取锁的等待时间都有一个合理的上界，而不会有
+// goroutine被新来者持续插队导致长期饿死
+func TestMutexNoLongTermStarvation(t *testing.T) {
+	var m Mutex
+
+	const goroutines = 8
+	const runFor = 200 * time.Millisecond
+	maxWait := make([]time.Duration, goroutines)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range maxWait {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				start := time.Now()
+				m.Lock()
+				if waited := time.Since(start); waited > maxWait[i] {
+					maxWait[i] = waited
+				}
+				m.Unlock()
+			}
+		}()
+	}
+
+	time.Sleep(runFor)
+	close(stop)
+	wg.Wait()
+
+	const starvationBound = time.Second
+	for i, waited := range maxWait {
+		if waited > starvationBound {
+			t.Errorf("goroutine #%d waited %v for a single Lock() call; want <= %v", i, waited, starvationBound)
+		}
+	}
+}
+
+func TestMutexNoDeadlockUnderContention(t *testing.T) {
+	var m Mutex
+	var done int32
+	var wg sync.WaitGroup
+
+	const goroutines = 32
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				m.Lock()
+				m.Unlock()
+			}
+			atomic.AddInt32(&done, 1)
+		}()
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("deadlock suspected: only %d/%d goroutines finished", atomic.LoadInt32(&done), goroutines)
+	}
+}
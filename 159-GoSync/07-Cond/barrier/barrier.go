@@ -0,0 +1,133 @@
+package barrier
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+/*
+meter-racing.go里裁判员/运动员的例子是一个一次性的栅栏：所有运动员都准备
+好之后裁判员才会打响发令枪，用的是一次性的sync.Cond+计数器，没法在下一轮
+比赛里复用。Barrier是同一模式的通用版本，可以反复使用，类似Java里的
+CyclicBarrier：
+
+1. parties个goroutine各自调用Await，都到齐之前一直阻塞在Cond.Wait上。
+2. 最后一个到达的goroutine（也就是裁判员）触发barrierAction，然后
+   Broadcast唤醒所有等待者，同时开始下一代，为下一轮Await做准备。
+3. 如果等待过程中ctx被取消，或者有人显式调用了Reset，当前这一代会被标记为
+   broken，正在等待这一代的goroutine都会收到ErrBrokenBarrier；下一代
+   从一张干净的状态开始，不会被上一代的broken影响。
+*/
+
+// ErrBrokenBarrier表示Barrier在有goroutine还未到齐时就被打破了，
+// 可能是因为某个Await的ctx被取消，也可能是显式调用了Reset
+var ErrBrokenBarrier = errors.New("barrier: broken barrier")
+
+// generation代表barrier的一代，每一代都有自己独立的broken状态，
+// 这样上一代的cancel/Reset不会影响到下一代新到达的goroutine
+type generation struct {
+	broken bool
+}
+
+// Barrier是一个可以循环使用的栅栏：parties个goroutine全部调用Await之后
+// 才会一起被释放，然后自动开始下一代
+type Barrier struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	parties int         // 一代需要凑齐的goroutine数量
+	count   int         // 当前这一代已经到达的数量
+	current *generation // 当前这一代，Broadcast之后会换成一个新的generation
+	action  func()      // 最后一个到达的goroutine在释放其它goroutine之前执行
+}
+
+// NewBarrier创建一个需要parties个goroutine才能通过的Barrier；
+// action可以为nil，非nil时会在每一代最后一个到达的goroutine里同步执行,
+// 执行完毕之后才会释放其它等待者（对应"裁判员打响发令枪"这一步）
+func NewBarrier(parties int, action func()) *Barrier {
+	if parties <= 0 {
+		panic("barrier: parties must be positive")
+	}
+	b := &Barrier{parties: parties, action: action, current: &generation{}}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Await阻塞直到parties个goroutine都调用了Await，或者ctx被取消，
+// 或者Barrier被其它goroutine打破。返回nil表示这一代已经正常放行。
+func (b *Barrier) Await(ctx context.Context) error {
+	b.mu.Lock()
+	g := b.current
+	if g.broken {
+		b.mu.Unlock()
+		return ErrBrokenBarrier
+	}
+	if err := ctx.Err(); err != nil {
+		b.mu.Unlock()
+		return err
+	}
+
+	b.count++
+	if b.count == b.parties { // 最后一个到达，负责放行整代goroutine
+		if b.action != nil {
+			b.action()
+		}
+		b.nextGenerationLocked()
+		b.mu.Unlock()
+		return nil
+	}
+
+	// 不是最后一个，需要挂起等待；同时用ctx watcher在取消时打破自己这一代
+	if done := ctx.Done(); done != nil {
+		stop := context.AfterFunc(ctx, func() { b.breakGeneration(g) })
+		defer stop()
+	}
+
+	for b.current == g && !g.broken {
+		b.cond.Wait()
+	}
+	broken := g.broken
+	b.mu.Unlock()
+
+	if broken {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		return ErrBrokenBarrier
+	}
+	return nil
+}
+
+// Reset打破当前这一代（唤醒所有正在等待的goroutine，让它们收到
+// ErrBrokenBarrier），然后立刻开始一张干净的下一代
+func (b *Barrier) Reset() {
+	b.mu.Lock()
+	b.current.broken = true
+	b.cond.Broadcast()
+	b.count = 0
+	b.current = &generation{}
+	b.mu.Unlock()
+}
+
+// Parties返回构造时传入的parties
+func (b *Barrier) Parties() int {
+	return b.parties
+}
+
+// breakGeneration只打破g这一代；如果barrier已经进入了更新的一代，
+// 说明g早就正常放行过了，不需要再做任何事
+func (b *Barrier) breakGeneration(g *generation) {
+	b.mu.Lock()
+	if b.current == g && !g.broken {
+		g.broken = true
+		b.cond.Broadcast()
+	}
+	b.mu.Unlock()
+}
+
+func (b *Barrier) nextGenerationLocked() {
+	b.cond.Broadcast()
+	b.count = 0
+	b.current = &generation{}
+}
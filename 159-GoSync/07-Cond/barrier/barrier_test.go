@@ -0,0 +1,113 @@
+package barrier
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBarrierReleasesAllPartiesTogether(t *testing.T) {
+	const parties = 10
+	var started int32
+	b := NewBarrier(parties, func() {
+		// 最后一个到达的goroutine执行，此时其它goroutine应该都还没通过
+		if got := atomic.LoadInt32(&started); got != 0 {
+			t.Errorf("barrierAction ran with %d goroutines already released; want 0", got)
+		}
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < parties; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := b.Await(context.Background()); err != nil {
+				t.Errorf("Await() = %v; want nil", err)
+			}
+			atomic.AddInt32(&started, 1)
+		}()
+	}
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&started); got != parties {
+		t.Fatalf("released goroutines = %d; want %d", got, parties)
+	}
+}
+
+func TestBarrierCyclesToNextGeneration(t *testing.T) {
+	b := NewBarrier(2, nil)
+
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		wg.Add(2)
+		for i := 0; i < 2; i++ {
+			go func() {
+				defer wg.Done()
+				if err := b.Await(context.Background()); err != nil {
+					t.Errorf("round %d: Await() = %v; want nil", round, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestBarrierAwaitCanceledByContext(t *testing.T) {
+	b := NewBarrier(2, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- b.Await(ctx) }()
+
+	// 确保上面的goroutine已经在Await里挂起了，再取消
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Fatalf("Await() = %v; want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Await() did not return after ctx was canceled")
+	}
+
+	// 被取消的这一代已经broken，之后到达同一代的goroutine应该立刻拿到ErrBrokenBarrier
+	if err := b.Await(context.Background()); err != ErrBrokenBarrier {
+		t.Fatalf("Await() after cancellation = %v; want ErrBrokenBarrier", err)
+	}
+}
+
+func TestBarrierReset(t *testing.T) {
+	b := NewBarrier(2, nil)
+
+	done := make(chan error, 1)
+	go func() { done <- b.Await(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+
+	b.Reset()
+
+	select {
+	case err := <-done:
+		if err != ErrBrokenBarrier {
+			t.Fatalf("Await() = %v; want ErrBrokenBarrier", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Await() did not return after Reset")
+	}
+
+	// Reset之后应该是一张干净的新generation，可以正常凑齐通过
+	var wg sync.WaitGroup
+	wg.Add(2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer wg.Done()
+			if err := b.Await(context.Background()); err != nil {
+				t.Errorf("Await() after Reset = %v; want nil", err)
+			}
+		}()
+	}
+	wg.Wait()
+}
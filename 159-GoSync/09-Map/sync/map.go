@@ -103,7 +103,7 @@ func (m *Map) LoadAndDelete(key any) (value any, loaded bool) {
 		}
 		m.mu.Unlock()
 	}
-	if !ok {
+	if ok {
 		return e.delete()
 	}
 	return nil, false
@@ -113,6 +113,169 @@ func (m *Map) Delete(key any) {
 	m.LoadAndDelete(key)
 }
 
+// LoadOrStore 如果key已经存在，就返回已存在的值（loaded为true）
+// 否则存储并返回给定的值（loaded为false）
+func (m *Map) LoadOrStore(key, value any) (actual any, loaded bool) {
+	// 先走一遍read的快速路径，如果read中已经有这个key，尝试直接原子地存进去
+	read, _ := m.read.Load().(readOnly)
+	if e, ok := read.m[key]; ok {
+		actual, loaded, ok := e.tryLoadOrStore(value)
+		if ok {
+			return actual, loaded
+		}
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnly)
+	if e, ok := read.m[key]; ok { // 双检查
+		if e.unexpungeLocked() {
+			// 之前被删除了，现在重新加回dirty
+			m.dirty[key] = e
+		}
+		actual, loaded, _ = e.tryLoadOrStore(value)
+	} else if e, ok := m.dirty[key]; ok { // dirty中已经有了
+		actual, loaded, _ = e.tryLoadOrStore(value)
+		m.missLocked()
+	} else { // 都没有，是一个新key
+		if !read.amended {
+			m.dirtyLocked()
+			m.read.Store(readOnly{m: read.m, amended: true})
+		}
+		m.dirty[key] = newEntry(value)
+		actual, loaded = value, false
+	}
+	m.mu.Unlock()
+
+	return actual, loaded
+}
+
+// Swap 用新值替换key对应的值，返回旧值previous，如果key之前不存在则loaded为false
+func (m *Map) Swap(key, value any) (previous any, loaded bool) {
+	read, _ := m.read.Load().(readOnly)
+	if e, ok := read.m[key]; ok {
+		if v, ok := e.trySwap(&value); ok {
+			if v == nil {
+				return nil, false
+			}
+			return *v, true
+		}
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnly)
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			// 之前被删除了，现在重新加回dirty
+			m.dirty[key] = e
+		}
+		if v, ok := e.trySwap(&value); ok {
+			m.mu.Unlock()
+			if v == nil {
+				return nil, false
+			}
+			return *v, true
+		}
+	} else if e, ok := m.dirty[key]; ok {
+		v, loaded := e.trySwap(&value)
+		m.missLocked()
+		m.mu.Unlock()
+		if v == nil {
+			return nil, loaded
+		}
+		return *v, loaded
+	} else {
+		if !read.amended {
+			m.dirtyLocked()
+			m.read.Store(readOnly{m: read.m, amended: true})
+		}
+		m.dirty[key] = newEntry(value)
+	}
+	m.mu.Unlock()
+	return nil, false
+}
+
+// CompareAndSwap 只有当key对应的值等于old时，才把它替换为new，返回是否替换成功
+func (m *Map) CompareAndSwap(key, old, new any) (swapped bool) {
+	read, _ := m.read.Load().(readOnly)
+	if e, ok := read.m[key]; ok {
+		return e.tryCompareAndSwap(old, new)
+	} else if !read.amended {
+		return false // 都没有dirty，key不可能存在
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	read, _ = m.read.Load().(readOnly)
+	swapped = false
+	if e, ok := read.m[key]; ok {
+		swapped = e.tryCompareAndSwap(old, new)
+	} else if e, ok := m.dirty[key]; ok {
+		swapped = e.tryCompareAndSwap(old, new)
+		// CompareAndSwap是一次读操作，即使失败也要计入miss，跟原生sync.Map保持一致
+		m.missLocked()
+	}
+	return swapped
+}
+
+// CompareAndDelete 只有当key对应的值等于old时，才删除这个key，返回是否删除成功
+// 如果key不存在，也会返回false
+func (m *Map) CompareAndDelete(key, old any) (deleted bool) {
+	read, _ := m.read.Load().(readOnly)
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnly)
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = m.dirty[key]
+			// 不管这次能不能删掉，都是一次miss
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	for ok {
+		p := atomic.LoadPointer(&e.p)
+		if p == nil || p == expunged || *(*any)(p) != old {
+			return false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// Range 依次对map中的每个key、value调用f，如果f返回false就提前结束遍历
+//
+// Range的遍历顺序不固定，且不保证是当前某一时刻map内容的一致快照：
+// 遍历过程中并发的Store、Delete最多只会被观察一次，可能观察不到也可能观察到。
+func (m *Map) Range(f func(key, value any) bool) {
+	// 如果dirty中有read没有的元素（amended为true），先把dirty提升为read，
+	// 这样可以避免在持有锁的情况下遍历（遍历f可能耗时很长，甚至递归调用到本Map上）
+	read, _ := m.read.Load().(readOnly)
+	if read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnly)
+		if read.amended {
+			read = readOnly{m: m.dirty}
+			m.read.Store(read)
+			m.dirty = nil
+			m.misses = 0
+		}
+		m.mu.Unlock()
+	}
+
+	for k, e := range read.m {
+		v, ok := e.load()
+		if !ok {
+			continue
+		}
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
 func (e *entry) delete() (value any, ok bool) {
 	for {
 		p := atomic.LoadPointer(&e.p)
@@ -187,6 +350,72 @@ func (e *entry) storeLocked(i *any) {
 	atomic.StorePointer(&e.p, unsafe.Pointer(i))
 }
 
+// tryLoadOrStore 如果entry没有被删除，原子地加载已有的值，
+// 或者在entry当前为nil的情况下存入给定的值。
+//
+// 如果entry被删除，tryLoadOrStore返回ok=false，调用方需要加锁重试。
+func (e *entry) tryLoadOrStore(i any) (actual any, loaded, ok bool) {
+	p := atomic.LoadPointer(&e.p)
+	if p == expunged {
+		return nil, false, false
+	}
+	if p != nil {
+		return *(*any)(p), true, true
+	}
+
+	// 这里拷贝了接口变量，避免每次CAS都在堆上分配新的any
+	ic := i
+	for {
+		if atomic.CompareAndSwapPointer(&e.p, nil, unsafe.Pointer(&ic)) {
+			return i, false, true
+		}
+		p = atomic.LoadPointer(&e.p)
+		if p == expunged {
+			return nil, false, false
+		}
+		if p != nil {
+			return *(*any)(p), true, true
+		}
+	}
+}
+
+// trySwap 如果entry没有被删除，用i替换它的值并返回旧值。
+//
+// 如果entry被删除，trySwap返回ok=false，调用方需要加锁重试。
+func (e *entry) trySwap(i *any) (*any, bool) {
+	for {
+		p := atomic.LoadPointer(&e.p)
+		if p == expunged {
+			return nil, false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(i)) {
+			if p == nil {
+				return nil, true
+			}
+			return (*any)(p), true
+		}
+	}
+}
+
+// tryCompareAndSwap 只有当entry的当前值等于old时，才把它替换为new
+func (e *entry) tryCompareAndSwap(old, new any) bool {
+	p := atomic.LoadPointer(&e.p)
+	if p == nil || p == expunged || *(*any)(p) != old {
+		return false
+	}
+
+	nc := new
+	for {
+		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(&nc)) {
+			return true
+		}
+		p = atomic.LoadPointer(&e.p)
+		if p == nil || p == expunged || *(*any)(p) != old {
+			return false
+		}
+	}
+}
+
 func (e *entry) tryExpungeLocked() (isExpunged bool) {
 	p := atomic.LoadPointer(&e.p)
 	for p == nil {
@@ -0,0 +1,142 @@
+package sync
+
+import "testing"
+
+// TestMapLoadStore 覆盖最基本的读写场景
+func TestMapLoadStore(t *testing.T) {
+	var m Map
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load(a) = %v, %v; want 1, true", v, ok)
+	}
+}
+
+// TestMapAmendedPromotion 验证miss达到阈值之后dirty会被提升为read，
+// 并且amended标记会被正确地重置
+func TestMapAmendedPromotion(t *testing.T) {
+	var m Map
+	m.Store("a", 1) // 触发dirtyLocked，amended变为true
+
+	read, _ := m.read.Load().(readOnly)
+	if !read.amended {
+		t.Fatalf("read.amended = false after first Store; want true")
+	}
+
+	// 反复Load一个只存在于dirty中的key，直到miss数达到len(dirty)，触发提升
+	for i := 0; i < 2; i++ {
+		if _, ok := m.Load("a"); !ok {
+			t.Fatalf("Load(a) miss #%d should still find the key in dirty", i)
+		}
+	}
+
+	read, _ = m.read.Load().(readOnly)
+	if read.amended {
+		t.Fatalf("read.amended = true after promotion; want false")
+	}
+	if _, ok := read.m["a"]; !ok {
+		t.Fatalf("key a should have been promoted into read")
+	}
+}
+
+// TestMapLoadOrStore 验证LoadOrStore在read和dirty两条路径上的行为
+func TestMapLoadOrStore(t *testing.T) {
+	var m Map
+	if actual, loaded := m.LoadOrStore("a", 1); loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 1) = %v, %v; want 1, false", actual, loaded)
+	}
+	if actual, loaded := m.LoadOrStore("a", 2); !loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 2) = %v, %v; want 1, true", actual, loaded)
+	}
+}
+
+// TestMapSwap 验证Swap返回旧值，且首次Swap时loaded为false
+func TestMapSwap(t *testing.T) {
+	var m Map
+	if previous, loaded := m.Swap("a", 1); loaded || previous != nil {
+		t.Fatalf("Swap(a, 1) = %v, %v; want nil, false", previous, loaded)
+	}
+	if previous, loaded := m.Swap("a", 2); !loaded || previous != 1 {
+		t.Fatalf("Swap(a, 2) = %v, %v; want 1, true", previous, loaded)
+	}
+}
+
+// TestMapCompareAndSwapAndDelete 验证CAS语义：值不匹配时应保持不变
+func TestMapCompareAndSwapAndDelete(t *testing.T) {
+	var m Map
+	m.Store("a", 1)
+
+	if m.CompareAndSwap("a", 2, 3) {
+		t.Fatalf("CompareAndSwap with wrong old value should fail")
+	}
+	if !m.CompareAndSwap("a", 1, 3) {
+		t.Fatalf("CompareAndSwap with correct old value should succeed")
+	}
+	if v, _ := m.Load("a"); v != 3 {
+		t.Fatalf("Load(a) = %v; want 3", v)
+	}
+
+	if m.CompareAndDelete("a", 2) {
+		t.Fatalf("CompareAndDelete with wrong old value should fail")
+	}
+	if !m.CompareAndDelete("a", 3) {
+		t.Fatalf("CompareAndDelete with correct old value should succeed")
+	}
+	if _, ok := m.Load("a"); ok {
+		t.Fatalf("Load(a) after CompareAndDelete should miss")
+	}
+}
+
+// TestMapLoadAndDelete 验证存在的key被删除后不能再被Load到，
+// 且对不存在的key调用不会panic
+func TestMapLoadAndDelete(t *testing.T) {
+	var m Map
+	m.Store("a", 1)
+
+	if v, loaded := m.LoadAndDelete("a"); !loaded || v != 1 {
+		t.Fatalf("LoadAndDelete(a) = %v, %v; want 1, true", v, loaded)
+	}
+	if _, ok := m.Load("a"); ok {
+		t.Fatalf("Load(a) after LoadAndDelete should miss")
+	}
+
+	if v, loaded := m.LoadAndDelete("missing"); loaded || v != nil {
+		t.Fatalf("LoadAndDelete(missing) = %v, %v; want nil, false", v, loaded)
+	}
+}
+
+// TestMapDelete 验证Delete之后key不再存在
+func TestMapDelete(t *testing.T) {
+	var m Map
+	m.Store("a", 1)
+	m.Delete("a")
+
+	if _, ok := m.Load("a"); ok {
+		t.Fatalf("Load(a) after Delete should miss")
+	}
+
+	// Delete一个不存在的key不应panic
+	m.Delete("missing")
+}
+
+// TestMapRange 验证Range既能看到read中的数据，也能看到amended之后dirty中的数据
+func TestMapRange(t *testing.T) {
+	var m Map
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	seen := make(map[any]any)
+	m.Range(func(key, value any) bool {
+		seen[key] = value
+		return true
+	})
+
+	if len(seen) != 2 || seen["a"] != 1 || seen["b"] != 2 {
+		t.Fatalf("Range collected %v; want a=1, b=2", seen)
+	}
+
+	// Range提升dirty之后amended应该被重置
+	read, _ := m.read.Load().(readOnly)
+	if read.amended {
+		t.Fatalf("read.amended = true after Range; want false")
+	}
+}
@@ -0,0 +1,146 @@
+package sync
+
+import (
+	"fmt"
+	"hash/maphash"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// ShardedMap 是sync.Map的一个替代方案，专门针对写多/覆盖写多的场景。
+//
+// sync.Map的miss会触发一次O(N)的dirty->read整体提升，写多的场景下这个提升
+// 会被频繁触发，反而比一把大锁的map还慢。ShardedMap把key哈希到固定数量的
+// 分片(shard)上，每个分片是一把普通的map加一把RWMutex，写操作只需要竞争
+// 自己所在分片的锁，天然把锁的粒度打散了。
+type ShardedMap struct {
+	shards []*mapShard
+	seed   maphash.Seed
+	mask   uint64 // len(shards)-1，shards数量总是2的幂，用位运算代替取模
+}
+
+type mapShard struct {
+	mu sync.RWMutex
+	m  map[any]any
+}
+
+// defaultShardCount 默认分片数，取GOMAXPROCS*16并向上取整到2的幂，
+// 让并发度越高的机器锁的粒度越细
+func defaultShardCount() int {
+	n := runtime.GOMAXPROCS(0) * 16
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	return size
+}
+
+// NewShardedMap 创建一个ShardedMap，shardCount会被向上取整到最近的2的幂；
+// 传入0或负数则使用默认的分片数
+func NewShardedMap(shardCount int) *ShardedMap {
+	if shardCount <= 0 {
+		shardCount = defaultShardCount()
+	} else {
+		size := 1
+		for size < shardCount {
+			size <<= 1
+		}
+		shardCount = size
+	}
+
+	sm := &ShardedMap{
+		shards: make([]*mapShard, shardCount),
+		seed:   maphash.MakeSeed(),
+		mask:   uint64(shardCount - 1),
+	}
+	for i := range sm.shards {
+		sm.shards[i] = &mapShard{m: make(map[any]any)}
+	}
+	return sm
+}
+
+// shardFor 计算key落在哪个分片上；对string和int做类型特化，避免maphash对any的反射开销，
+// 其它类型统一转成字符串再走哈希的慢路径
+func (sm *ShardedMap) shardFor(key any) *mapShard {
+	var h maphash.Hash
+	h.SetSeed(sm.seed)
+	switch k := key.(type) {
+	case string:
+		h.WriteString(k)
+	case int:
+		h.WriteString(strconv.Itoa(k))
+	default:
+		// 兜底方案：借助%v把任意可比较的key转成字符串参与哈希，
+		// 分布不如专门的类型特化均匀，但正确性不受影响
+		h.WriteString(fmt.Sprintf("%v", key))
+	}
+	return sm.shards[h.Sum64()&sm.mask]
+}
+
+func (m *ShardedMap) Load(key any) (value any, ok bool) {
+	shard := m.shardFor(key)
+	shard.mu.RLock()
+	value, ok = shard.m[key]
+	shard.mu.RUnlock()
+	return value, ok
+}
+
+func (m *ShardedMap) Store(key, value any) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	shard.m[key] = value
+	shard.mu.Unlock()
+}
+
+func (m *ShardedMap) Delete(key any) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	delete(shard.m, key)
+	shard.mu.Unlock()
+}
+
+func (m *ShardedMap) LoadOrStore(key, value any) (actual any, loaded bool) {
+	shard := m.shardFor(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	if actual, loaded = shard.m[key]; loaded {
+		return actual, true
+	}
+	shard.m[key] = value
+	return value, false
+}
+
+// Range 依次对每个分片遍历，分片之间没有全局锁，因此和sync.Map一样，
+// 不保证是某一时刻整个map的一致快照。
+//
+// 每个分片先在读锁下拷贝出一份快照再释放锁，然后在锁外调用f，避免f里
+// 对同一分片的Store/Delete/LoadOrStore（常见的"遍历时删除"用法）反过来
+// 竞争shard.mu造成自锁死锁，和Map.Range对同一问题的处理方式一致。
+func (m *ShardedMap) Range(f func(key, value any) bool) {
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		snapshot := make(map[any]any, len(shard.m))
+		for k, v := range shard.m {
+			snapshot[k] = v
+		}
+		shard.mu.RUnlock()
+
+		for k, v := range snapshot {
+			if !f(k, v) {
+				return
+			}
+		}
+	}
+}
+
+// Len 返回所有分片长度之和，遍历期间分片之间没有互斥，只是一个近似值
+func (m *ShardedMap) Len() int {
+	n := 0
+	for _, shard := range m.shards {
+		shard.mu.RLock()
+		n += len(shard.m)
+		shard.mu.RUnlock()
+	}
+	return n
+}
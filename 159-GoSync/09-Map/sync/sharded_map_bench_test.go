@@ -0,0 +1,62 @@
+package sync
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// benchKeys 预先生成一批key，避免基准测试的热路径里包含字符串拼接的开销
+func benchKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+	}
+	return keys
+}
+
+// runMapBench 让goroutine并发地按writePercent的比例做写(Store)/读(Load)，
+// 用来模拟不同读写比例下的真实负载
+func runMapBench(b *testing.B, writePercent int, newLoad func() func(key string) (any, bool), store func(key string, value any)) {
+	keys := benchKeys(1024)
+	for _, k := range keys {
+		store(k, 0)
+	}
+	load := newLoad()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewSource(rand.Int63()))
+		for pb.Next() {
+			key := keys[r.Intn(len(keys))]
+			if r.Intn(100) < writePercent {
+				store(key, r.Int())
+			} else {
+				load(key)
+			}
+		}
+	})
+}
+
+func benchmarkSyncMap(b *testing.B, writePercent int) {
+	var m Map
+	runMapBench(b, writePercent,
+		func() func(string) (any, bool) { return func(key string) (any, bool) { return m.Load(key) } },
+		func(key string, value any) { m.Store(key, value) },
+	)
+}
+
+func benchmarkShardedMap(b *testing.B, writePercent int) {
+	m := NewShardedMap(0)
+	runMapBench(b, writePercent,
+		func() func(string) (any, bool) { return func(key string) (any, bool) { return m.Load(key) } },
+		func(key string, value any) { m.Store(key, value) },
+	)
+}
+
+func BenchmarkSyncMap_Read90Write10(b *testing.B)    { benchmarkSyncMap(b, 10) }
+func BenchmarkSyncMap_Read50Write50(b *testing.B)    { benchmarkSyncMap(b, 50) }
+func BenchmarkSyncMap_Read10Write90(b *testing.B)    { benchmarkSyncMap(b, 90) }
+func BenchmarkShardedMap_Read90Write10(b *testing.B) { benchmarkShardedMap(b, 10) }
+func BenchmarkShardedMap_Read50Write50(b *testing.B) { benchmarkShardedMap(b, 50) }
+func BenchmarkShardedMap_Read10Write90(b *testing.B) { benchmarkShardedMap(b, 90) }
@@ -0,0 +1,82 @@
+package sync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShardedMapLoadStoreDelete(t *testing.T) {
+	m := NewShardedMap(0)
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load(a) = %v, %v; want 1, true", v, ok)
+	}
+
+	m.Delete("a")
+	if _, ok := m.Load("a"); ok {
+		t.Fatalf("Load(a) after Delete should miss")
+	}
+}
+
+func TestShardedMapLoadOrStore(t *testing.T) {
+	m := NewShardedMap(0)
+	if actual, loaded := m.LoadOrStore("a", 1); loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 1) = %v, %v; want 1, false", actual, loaded)
+	}
+	if actual, loaded := m.LoadOrStore("a", 2); !loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 2) = %v, %v; want 1, true", actual, loaded)
+	}
+}
+
+func TestShardedMapRangeAndLen(t *testing.T) {
+	m := NewShardedMap(4) // 会被向上取整为4
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	if n := m.Len(); n != 2 {
+		t.Fatalf("Len() = %d; want 2", n)
+	}
+
+	seen := make(map[any]any)
+	m.Range(func(key, value any) bool {
+		seen[key] = value
+		return true
+	})
+	if len(seen) != 2 || seen["a"] != 1 || seen["b"] != 2 {
+		t.Fatalf("Range collected %v; want a=1, b=2", seen)
+	}
+}
+
+// TestShardedMapRangeAllowsDeleteOnSameShard 验证Range的回调里对同一分片
+// 调用Delete不会因为回调仍持有shard.mu而自锁死锁
+func TestShardedMapRangeAllowsDeleteOnSameShard(t *testing.T) {
+	m := NewShardedMap(1) // 单分片，key必然落在Range正在遍历的分片上
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	done := make(chan struct{})
+	go func() {
+		m.Range(func(key, value any) bool {
+			m.Delete(key)
+			return true
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Range deadlocked when callback deleted from the shard being ranged")
+	}
+
+	if n := m.Len(); n != 0 {
+		t.Fatalf("Len() = %d after deleting during Range; want 0", n)
+	}
+}
+
+func TestShardedMapShardCountRoundsUpToPowerOfTwo(t *testing.T) {
+	m := NewShardedMap(10)
+	if len(m.shards) != 16 {
+		t.Fatalf("len(shards) = %d; want 16", len(m.shards))
+	}
+}
@@ -0,0 +1,396 @@
+package sync
+
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// TypedMap是对Map的一层强类型封装，内部复用同一套read/dirty/expunged协议，
+// 但entry里存的是*V而不是*any，Store的时候不用把V装箱成interface{}，
+// 调用方也不用在每个Load的地方再做一次类型断言。
+type TypedMap[K comparable, V any] struct {
+	mu sync.Mutex
+	// 与Map.read含义相同，只是元素类型换成了readOnlyG[K, V]
+	read atomic.Value // readOnlyG[K, V]
+
+	// 与Map.dirty含义相同
+	dirty map[K]*typedEntry[V]
+
+	// 与Map.misses含义相同
+	misses int
+}
+
+type readOnlyG[K comparable, V any] struct {
+	m       map[K]*typedEntry[V]
+	amended bool
+}
+
+// typedEntry和entry是同一套expunged指针协议，只是把p的指向类型从*any换成了*V，
+// 这样Store一个值只需要一次堆分配（分配V本身），不需要再多一次装箱分配
+type typedEntry[V any] struct {
+	p unsafe.Pointer // *V
+}
+
+func newTypedEntry[V any](v V) *typedEntry[V] {
+	return &typedEntry[V]{p: unsafe.Pointer(&v)}
+}
+
+func (m *TypedMap[K, V]) Load(key K) (value V, ok bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		m.mu.Lock()
+		read, _ := m.read.Load().(readOnlyG[K, V])
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = m.dirty[key]
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	return e.load()
+}
+
+func (m *TypedMap[K, V]) Store(key K, value V) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok && e.tryStore(&value) {
+		return
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			m.dirty[key] = e
+		}
+		e.storeLocked(&value)
+	} else if e, ok := m.dirty[key]; ok {
+		e.storeLocked(&value)
+	} else {
+		if !read.amended {
+			m.dirtyLocked()
+			m.read.Store(readOnlyG[K, V]{m: read.m, amended: true})
+		}
+		m.dirty[key] = newTypedEntry(value)
+	}
+	m.mu.Unlock()
+}
+
+func (m *TypedMap[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		actual, loaded, ok := e.tryLoadOrStore(value)
+		if ok {
+			return actual, loaded
+		}
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			m.dirty[key] = e
+		}
+		actual, loaded, _ = e.tryLoadOrStore(value)
+	} else if e, ok := m.dirty[key]; ok {
+		actual, loaded, _ = e.tryLoadOrStore(value)
+		m.missLocked()
+	} else {
+		if !read.amended {
+			m.dirtyLocked()
+			m.read.Store(readOnlyG[K, V]{m: read.m, amended: true})
+		}
+		m.dirty[key] = newTypedEntry(value)
+		actual, loaded = value, false
+	}
+	m.mu.Unlock()
+
+	return actual, loaded
+}
+
+func (m *TypedMap[K, V]) LoadAndDelete(key K) (value V, loaded bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnlyG[K, V])
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = m.dirty[key]
+			delete(m.dirty, key)
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	if ok {
+		return e.delete()
+	}
+	var zero V
+	return zero, false
+}
+
+func (m *TypedMap[K, V]) Delete(key K) {
+	m.LoadAndDelete(key)
+}
+
+func (m *TypedMap[K, V]) Swap(key K, value V) (previous V, loaded bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		if v, ok := e.trySwap(&value); ok {
+			if v == nil {
+				var zero V
+				return zero, false
+			}
+			return *v, true
+		}
+	}
+
+	m.mu.Lock()
+	read, _ = m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		if e.unexpungeLocked() {
+			m.dirty[key] = e
+		}
+		if v, ok := e.trySwap(&value); ok {
+			m.mu.Unlock()
+			if v == nil {
+				var zero V
+				return zero, false
+			}
+			return *v, true
+		}
+	} else if e, ok := m.dirty[key]; ok {
+		v, loaded := e.trySwap(&value)
+		m.missLocked()
+		m.mu.Unlock()
+		if v == nil {
+			var zero V
+			return zero, loaded
+		}
+		return *v, loaded
+	} else {
+		if !read.amended {
+			m.dirtyLocked()
+			m.read.Store(readOnlyG[K, V]{m: read.m, amended: true})
+		}
+		m.dirty[key] = newTypedEntry(value)
+	}
+	m.mu.Unlock()
+	var zero V
+	return zero, false
+}
+
+// CompareAndSwap的语义和Map.CompareAndSwap一样，只是把any的比较换成了
+// any(V)的比较，如果V的动态类型不可比较（比如slice、map、func），会panic，
+// 这一点和标准库sync.Map对interface{}值做CompareAndSwap是一致的。
+func (m *TypedMap[K, V]) CompareAndSwap(key K, old, new V) (swapped bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	if e, ok := read.m[key]; ok {
+		return e.tryCompareAndSwap(old, new)
+	} else if !read.amended {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	read, _ = m.read.Load().(readOnlyG[K, V])
+	swapped = false
+	if e, ok := read.m[key]; ok {
+		swapped = e.tryCompareAndSwap(old, new)
+	} else if e, ok := m.dirty[key]; ok {
+		swapped = e.tryCompareAndSwap(old, new)
+		m.missLocked()
+	}
+	return swapped
+}
+
+func (m *TypedMap[K, V]) CompareAndDelete(key K, old V) (deleted bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	e, ok := read.m[key]
+	if !ok && read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnlyG[K, V])
+		e, ok = read.m[key]
+		if !ok && read.amended {
+			e, ok = m.dirty[key]
+			m.missLocked()
+		}
+		m.mu.Unlock()
+	}
+	for ok {
+		p := atomic.LoadPointer(&e.p)
+		if p == nil || p == expunged || any(*(*V)(p)) != any(old) {
+			return false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, nil) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *TypedMap[K, V]) Range(f func(key K, value V) bool) {
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	if read.amended {
+		m.mu.Lock()
+		read, _ = m.read.Load().(readOnlyG[K, V])
+		if read.amended {
+			read = readOnlyG[K, V]{m: m.dirty}
+			m.read.Store(read)
+			m.dirty = nil
+			m.misses = 0
+		}
+		m.mu.Unlock()
+	}
+
+	for k, e := range read.m {
+		v, ok := e.load()
+		if !ok {
+			continue
+		}
+		if !f(k, v) {
+			break
+		}
+	}
+}
+
+func (m *TypedMap[K, V]) missLocked() {
+	m.misses++
+	if m.misses < len(m.dirty) {
+		return
+	}
+	m.read.Store(readOnlyG[K, V]{m: m.dirty})
+	m.dirty = nil
+	m.misses = 0
+}
+
+func (m *TypedMap[K, V]) dirtyLocked() {
+	if m.dirty != nil {
+		return
+	}
+
+	read, _ := m.read.Load().(readOnlyG[K, V])
+	m.dirty = make(map[K]*typedEntry[V], len(read.m))
+	for k, e := range read.m {
+		if !e.tryExpungeLocked() {
+			m.dirty[k] = e
+		}
+	}
+}
+
+func (e *typedEntry[V]) load() (value V, ok bool) {
+	p := atomic.LoadPointer(&e.p)
+	if p == nil || p == expunged {
+		var zero V
+		return zero, false
+	}
+	return *(*V)(p), true
+}
+
+func (e *typedEntry[V]) delete() (value V, ok bool) {
+	for {
+		p := atomic.LoadPointer(&e.p)
+		if p == nil || p == expunged {
+			var zero V
+			return zero, false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, nil) {
+			return *(*V)(p), true
+		}
+	}
+}
+
+func (e *typedEntry[V]) tryStore(i *V) bool {
+	for {
+		p := atomic.LoadPointer(&e.p)
+		if p == expunged {
+			return false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(i)) {
+			return true
+		}
+	}
+}
+
+func (e *typedEntry[V]) unexpungeLocked() (wasExpunged bool) {
+	return atomic.CompareAndSwapPointer(&e.p, expunged, nil)
+}
+
+func (e *typedEntry[V]) storeLocked(i *V) {
+	atomic.StorePointer(&e.p, unsafe.Pointer(i))
+}
+
+func (e *typedEntry[V]) tryExpungeLocked() (isExpunged bool) {
+	p := atomic.LoadPointer(&e.p)
+	for p == nil {
+		if atomic.CompareAndSwapPointer(&e.p, nil, expunged) {
+			return true
+		}
+		p = atomic.LoadPointer(&e.p)
+	}
+	return p == expunged
+}
+
+func (e *typedEntry[V]) tryLoadOrStore(i V) (actual V, loaded, ok bool) {
+	p := atomic.LoadPointer(&e.p)
+	if p == expunged {
+		var zero V
+		return zero, false, false
+	}
+	if p != nil {
+		return *(*V)(p), true, true
+	}
+
+	ic := i
+	for {
+		if atomic.CompareAndSwapPointer(&e.p, nil, unsafe.Pointer(&ic)) {
+			return i, false, true
+		}
+		p = atomic.LoadPointer(&e.p)
+		if p == expunged {
+			var zero V
+			return zero, false, false
+		}
+		if p != nil {
+			return *(*V)(p), true, true
+		}
+	}
+}
+
+func (e *typedEntry[V]) trySwap(i *V) (*V, bool) {
+	for {
+		p := atomic.LoadPointer(&e.p)
+		if p == expunged {
+			return nil, false
+		}
+		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(i)) {
+			if p == nil {
+				return nil, true
+			}
+			return (*V)(p), true
+		}
+	}
+}
+
+func (e *typedEntry[V]) tryCompareAndSwap(old, new V) bool {
+	p := atomic.LoadPointer(&e.p)
+	if p == nil || p == expunged || any(*(*V)(p)) != any(old) {
+		return false
+	}
+
+	nc := new
+	for {
+		if atomic.CompareAndSwapPointer(&e.p, p, unsafe.Pointer(&nc)) {
+			return true
+		}
+		p = atomic.LoadPointer(&e.p)
+		if p == nil || p == expunged || any(*(*V)(p)) != any(old) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package sync
+
+import "testing"
+
+func TestTypedMapLoadStoreDelete(t *testing.T) {
+	var m TypedMap[string, int]
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load(a) = %v, %v; want 1, true", v, ok)
+	}
+
+	m.Delete("a")
+	if _, ok := m.Load("a"); ok {
+		t.Fatalf("Load(a) after Delete should miss")
+	}
+}
+
+func TestTypedMapLoadOrStore(t *testing.T) {
+	var m TypedMap[string, int]
+	if actual, loaded := m.LoadOrStore("a", 1); loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 1) = %v, %v; want 1, false", actual, loaded)
+	}
+	if actual, loaded := m.LoadOrStore("a", 2); !loaded || actual != 1 {
+		t.Fatalf("LoadOrStore(a, 2) = %v, %v; want 1, true", actual, loaded)
+	}
+}
+
+func TestTypedMapSwap(t *testing.T) {
+	var m TypedMap[string, int]
+	if previous, loaded := m.Swap("a", 1); loaded || previous != 0 {
+		t.Fatalf("Swap(a, 1) = %v, %v; want 0, false", previous, loaded)
+	}
+	if previous, loaded := m.Swap("a", 2); !loaded || previous != 1 {
+		t.Fatalf("Swap(a, 2) = %v, %v; want 1, true", previous, loaded)
+	}
+}
+
+func TestTypedMapCompareAndSwapAndDelete(t *testing.T) {
+	var m TypedMap[string, int]
+	m.Store("a", 1)
+
+	if m.CompareAndSwap("a", 2, 3) {
+		t.Fatalf("CompareAndSwap with wrong old value should fail")
+	}
+	if !m.CompareAndSwap("a", 1, 3) {
+		t.Fatalf("CompareAndSwap with correct old value should succeed")
+	}
+
+	if m.CompareAndDelete("a", 2) {
+		t.Fatalf("CompareAndDelete with wrong old value should fail")
+	}
+	if !m.CompareAndDelete("a", 3) {
+		t.Fatalf("CompareAndDelete with correct old value should succeed")
+	}
+}
+
+func TestTypedMapRange(t *testing.T) {
+	var m TypedMap[string, int]
+	m.Store("a", 1)
+	m.Store("b", 2)
+
+	seen := make(map[string]int)
+	m.Range(func(key string, value int) bool {
+		seen[key] = value
+		return true
+	})
+
+	if len(seen) != 2 || seen["a"] != 1 || seen["b"] != 2 {
+		t.Fatalf("Range collected %v; want a=1, b=2", seen)
+	}
+}
+
+// TestTypedMapLoadAllocs 验证一旦key被提升到read之后，Load的快速路径
+// 不会再产生任何堆分配（不用像Map[any]那样为interface装箱）
+func TestTypedMapLoadAllocs(t *testing.T) {
+	var m TypedMap[string, int]
+	m.Store("a", 1)
+	// 触发一次miss，把dirty提升为read
+	m.Load("a")
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		if _, ok := m.Load("a"); !ok {
+			t.Fatal("Load(a) unexpectedly missed")
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("Load allocated %v times per run on the read fast path; want 0", allocs)
+	}
+}
@@ -1,36 +1,85 @@
 package value
 
 import (
-	"math/rand"
+	"context"
 	"sync"
-	"sync/atomic"
 	"testing"
 	"time"
 )
 
-func TestValue(t *testing.T) {
-	var config atomic.Value
-	config.Store(loadNewConfig())
-	var cond = sync.NewCond(&sync.Mutex{})
-
-	// 设置新的config
-	go func() {
-		for {
-			time.Sleep(time.Duration(5+rand.Int63n(5)) * time.Second)
-			config.Store(loadNewConfig())
-			cond.Broadcast() // 通知等待着配置已变更
+// TestWatchedStoreLoad验证Store之后Load能立刻看到最新值
+func TestWatchedStoreLoad(t *testing.T) {
+	w := NewWatched(1)
+	if got := w.Load(); got != 1 {
+		t.Fatalf("Load() = %d; want 1", got)
+	}
+
+	w.Store(2)
+	if got := w.Load(); got != 2 {
+		t.Fatalf("Load() after Store(2) = %d; want 2", got)
+	}
+}
+
+// TestWatchedSubscribeCoalesces验证Subscribe返回的channel是"合并"的：
+// 订阅者哪怕来晚了、错过了中间几次Store，下一次接收到的也一定是最新值，
+// 而不是永远读不到更新（这正是原来atomic.Value+Cond版本的竞态所在）
+func TestWatchedSubscribeCoalesces(t *testing.T) {
+	w := NewWatched(0)
+	ch, cancel := w.Subscribe()
+	defer cancel()
+
+	// 订阅者还没来得及接收，Store就已经发生了好几次
+	for i := 1; i <= 5; i++ {
+		w.Store(i)
+	}
+
+	select {
+	case got := <-ch:
+		if got != 5 {
+			t.Fatalf("received %d; want the latest value 5", got)
 		}
-	}()
-
-	go func() {
-		for {
-			cond.L.Lock()
-			cond.Wait()                 // 等待变更信号
-			c := config.Load().(Config) // 读取新的配置
-			t.Logf("new config: %+v", c)
-			cond.L.Unlock()
+	case <-time.After(time.Second):
+		t.Fatal("did not receive the coalesced update")
+	}
+}
+
+// TestWatchedWatchFunc验证WatchFunc会在值变更之后被调用，最终一定能观察到
+// 最新的值，并且在ctx取消之后停止调用回调。由于channel是合并语义，中间
+// 几次Store有可能被跳过，所以这里只断言最终收到的是最新值3，不断言收到
+// 了恰好3次回调。
+func TestWatchedWatchFunc(t *testing.T) {
+	w := NewWatched(0)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var mu sync.Mutex
+	var last int
+	done := make(chan struct{})
+	var once sync.Once
+	w.WatchFunc(ctx, func(v int) {
+		mu.Lock()
+		last = v
+		mu.Unlock()
+		if v == 3 {
+			once.Do(func() { close(done) })
 		}
-	}()
+	})
+
+	w.Store(1)
+	w.Store(2)
+	w.Store(3)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WatchFunc did not observe the latest value in time")
+	}
+
+	cancel()
 
-	select {}
+	mu.Lock()
+	got := last
+	mu.Unlock()
+	if got != 3 {
+		t.Fatalf("last observed value = %d; want 3", got)
+	}
 }
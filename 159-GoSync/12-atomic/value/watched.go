@@ -0,0 +1,105 @@
+package value
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+/*
+上面TestValue里演示的atomic.Value+sync.Cond的组合是有竞态的：如果Store和
+Broadcast发生在订阅者调用Wait之前，这次变更通知就彻底丢了——订阅者会一直
+Wait下去，直到下一次变更才会被唤醒，期间读到的都是旧值。
+
+Watched把发布和订阅这两件事拆开来看：Store永远原子地发布最新值（Load不用
+加锁），订阅者拿到的是一个容量为1的channel，Store的时候非阻塞地往每个
+订阅者的channel里塞最新值——channel满了（说明订阅者还没消费上一次的更新）
+就直接换成最新值，这样订阅者不管什么时候来接收，下一次收到的一定是当前
+最新的值，不会因为错过了Broadcast就永远读不到更新。
+*/
+
+// Watched持有一个类型为T的当前值，并支持多个订阅者以推送的方式获知变更
+type Watched[T any] struct {
+	v atomic.Pointer[T]
+
+	mu   sync.Mutex
+	subs []chan T
+}
+
+// NewWatched创建一个初始值为initial的Watched
+func NewWatched[T any](initial T) *Watched[T] {
+	w := &Watched[T]{}
+	w.v.Store(&initial)
+	return w
+}
+
+// Load返回当前值，不需要加锁
+func (w *Watched[T]) Load() T {
+	return *w.v.Load()
+}
+
+// Store发布一个新值，然后非阻塞地通知每一个订阅者
+func (w *Watched[T]) Store(value T) {
+	w.v.Store(&value)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, ch := range w.subs {
+		notify(ch, value)
+	}
+}
+
+// notify把value塞进容量为1的ch里；如果ch里已经有一个还没被消费的旧值，
+// 就把它换成value，保证订阅者下次收到的永远是最新值
+func notify[T any](ch chan T, value T) {
+	for {
+		select {
+		case ch <- value:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// Subscribe返回一个会持续收到最新值的channel，以及一个取消订阅的函数。
+// 调用cancel之后应该不再从返回的channel里接收。
+func (w *Watched[T]) Subscribe() (<-chan T, func()) {
+	ch := make(chan T, 1)
+
+	w.mu.Lock()
+	w.subs = append(w.subs, ch)
+	w.mu.Unlock()
+
+	cancel := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		for i, c := range w.subs {
+			if c == ch {
+				w.subs = append(w.subs[:i], w.subs[i+1:]...)
+				return
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// WatchFunc订阅Watched，并在每次值发生变更时（在一个独立的goroutine里）
+// 调用f，直到ctx被取消。
+func (w *Watched[T]) WatchFunc(ctx context.Context, f func(T)) {
+	ch, cancel := w.Subscribe()
+	go func() {
+		defer cancel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case v := <-ch:
+				f(v)
+			}
+		}
+	}()
+}